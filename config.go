@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configPathKey     = "CONFIG_PATH"
+	defaultConfigPath = "config.yaml"
+)
+
+// StationConfig describes a single JMA observation station to monitor: the
+// station code used in the JMA CSV, a human-readable display name, the
+// precipitation (mm/h) at which it should start notifying, and an optional
+// override Slack webhook for this station alone.
+type StationConfig struct {
+	ID              string  `yaml:"id"`
+	Name            string  `yaml:"name"`
+	ThresholdMM     float64 `yaml:"threshold_mm"`
+	SlackWebhookURL string  `yaml:"slack_webhook_url,omitempty"`
+	// ForecastAreaCode is the JMA forecast area code (as used in
+	// forecast/data/forecast/<code>.json) to pull lead-time forecasts
+	// from. Forecasts are skipped for a station when this is unset.
+	ForecastAreaCode string `yaml:"forecast_area_code,omitempty"`
+}
+
+// Config is the top level configuration loaded once at Lambda init.
+type Config struct {
+	Stations []StationConfig `yaml:"stations"`
+}
+
+// loadConfig reads the station configuration from the path given by the
+// CONFIG_PATH environment variable, falling back to defaultConfigPath when
+// it isn't set.
+func loadConfig() (*Config, error) {
+	path := os.Getenv(configPathKey)
+	if path == "" {
+		path = defaultConfigPath
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cfg Config
+	if err := yaml.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Stations) == 0 {
+		return nil, errors.New("設定に観測所が1つも定義されていません")
+	}
+	return &cfg, nil
+}
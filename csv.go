@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jmaColumnNames maps the logical fields extractPrecipitations needs to the
+// column header JMA's CSV uses for them. Resolving columns by name, rather
+// than by a fixed index, keeps this working as JMA adds columns over time.
+var jmaColumnNames = map[string]string{
+	"station":       "観測所番号",
+	"year":          "年",
+	"month":         "月",
+	"day":           "日",
+	"hour":          "時",
+	"minute":        "分",
+	"precipitation": "降水量(mm)",
+}
+
+// extractPrecipitations does a single pass over the JMA CSV and returns the
+// latest precipitation observation for each requested station, keyed by
+// station ID. Stations whose latest row has no usable reading (missing or
+// marked unreliable) are simply absent from the result.
+func extractPrecipitations(reader io.Reader, stations []StationConfig) (map[string]Precipitation, error) {
+	wanted := make(map[string]struct{}, len(stations))
+	for _, s := range stations {
+		wanted[s.ID] = struct{}{}
+	}
+
+	csvReader := csv.NewReader(transform.NewReader(reader, japanese.ShiftJIS.NewDecoder()))
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSVにヘッダー行が見つかりませんでした")
+		}
+		return nil, err
+	}
+	columns, err := resolveColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Precipitation, len(stations))
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		stationID := record[columns["station"]]
+		if _, ok := wanted[stationID]; !ok {
+			continue
+		}
+
+		precipitation, ok, err := parsePrecipitationRecord(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("観測所%sのデータを解析できませんでした: %w", stationID, err)
+		}
+		if !ok {
+			delete(result, stationID)
+			continue
+		}
+		result[stationID] = precipitation
+	}
+	return result, nil
+}
+
+// resolveColumns maps each logical field in jmaColumnNames to its index in
+// the given header row.
+func resolveColumns(header []string) (map[string]int, error) {
+	indexOf := make(map[string]int, len(header))
+	for i, name := range header {
+		indexOf[name] = i
+	}
+
+	columns := make(map[string]int, len(jmaColumnNames))
+	for field, columnName := range jmaColumnNames {
+		idx, ok := indexOf[columnName]
+		if !ok {
+			return nil, fmt.Errorf("CSVヘッダーに%q列が見つかりませんでした", columnName)
+		}
+		columns[field] = idx
+	}
+	return columns, nil
+}
+
+// parsePrecipitationRecord builds a Precipitation from one CSV record given
+// the resolved column indices. It returns ok=false, rather than an error,
+// for JMA's routine "no data yet" markers: "--" for a missing value, and a
+// trailing ")" marking a reading as unreliable (e.g. during maintenance).
+func parsePrecipitationRecord(record []string, columns map[string]int) (precipitation Precipitation, ok bool, err error) {
+	date, err := time.Parse(time.RFC3339, fmt.Sprintf("%s-%s-%sT%s:%s:00+09:00",
+		record[columns["year"]],
+		record[columns["month"]],
+		record[columns["day"]],
+		record[columns["hour"]],
+		record[columns["minute"]],
+	))
+	if err != nil {
+		return Precipitation{}, false, err
+	}
+
+	raw := strings.TrimSpace(record[columns["precipitation"]])
+	if raw == "--" || raw == "" || strings.HasSuffix(raw, ")") {
+		return Precipitation{}, false, nil
+	}
+	mm, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return Precipitation{}, false, err
+	}
+
+	return Precipitation{date: date, precipitation: mm}, true, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtractPrecipitations(t *testing.T) {
+	cases := []struct {
+		name     string
+		file     string
+		stations []StationConfig
+		want     map[string]Precipitation
+	}{
+		{
+			name:     "normal reading",
+			file:     "testdata/normal.csv",
+			stations: []StationConfig{{ID: "61286", Name: "京都"}},
+			want: map[string]Precipitation{
+				"61286": {date: mustParseTime(t, "2026-07-25T10:00:00+09:00"), precipitation: 3.0},
+			},
+		},
+		{
+			name:     "missing value (--) is skipped",
+			file:     "testdata/missing_value.csv",
+			stations: []StationConfig{{ID: "44132", Name: "test"}},
+			want:     map[string]Precipitation{},
+		},
+		{
+			name:     "unreliable-data marker ()) is skipped",
+			file:     "testdata/unreliable_data.csv",
+			stations: []StationConfig{{ID: "12345", Name: "test"}},
+			want:     map[string]Precipitation{},
+		},
+		{
+			name:     "10-minute interval rows keep the latest",
+			file:     "testdata/ten_minute_interval.csv",
+			stations: []StationConfig{{ID: "61286", Name: "京都"}},
+			want: map[string]Precipitation{
+				"61286": {date: mustParseTime(t, "2026-07-25T10:20:00+09:00"), precipitation: 5.0},
+			},
+		},
+		{
+			// A realistic JMA header: extra columns (region, station name,
+			// temperature, wind speed) interspersed before, between, and
+			// after the ones we need, in a different order than the old
+			// fixed-index parser assumed. Exercises resolveColumns actually
+			// resolving columns by name instead of position.
+			name:     "realistic header with extra and reordered columns",
+			file:     "testdata/realistic_header.csv",
+			stations: []StationConfig{{ID: "61286", Name: "京都"}},
+			want: map[string]Precipitation{
+				"61286": {date: mustParseTime(t, "2026-07-25T10:00:00+09:00"), precipitation: 3.0},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := os.Open(tc.file)
+			if err != nil {
+				t.Fatalf("failed to open fixture: %v", err)
+			}
+			defer f.Close()
+
+			got, err := extractPrecipitations(f, tc.stations)
+			if err != nil {
+				t.Fatalf("extractPrecipitations() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("extractPrecipitations() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveColumnsMissingColumn(t *testing.T) {
+	_, err := resolveColumns([]string{"観測所番号", "年", "月", "日", "時", "分"})
+	if err == nil {
+		t.Fatal("resolveColumns() error = nil, want an error for the missing precipitation column")
+	}
+}
+
+func mustParseTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatalf("failed to parse time fixture %q: %v", s, err)
+	}
+	return parsed
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// DynamoStore is the default Store backend: it persists state in a
+// DynamoDB table keyed by station ID, so notification state survives
+// across Lambda invocations.
+type DynamoStore struct {
+	TableName string
+	client    *dynamodb.DynamoDB
+}
+
+// NewDynamoStore builds a DynamoStore against tableName using the Lambda's
+// default AWS session and credentials.
+func NewDynamoStore(tableName string) (*DynamoStore, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	return &DynamoStore{TableName: tableName, client: dynamodb.New(sess)}, nil
+}
+
+// dynamoStationItem is the DynamoDB-side representation of StationState,
+// keyed by station ID.
+type dynamoStationItem struct {
+	StationID            string `dynamodbav:"stationId"`
+	LastObservationTime  int64  `dynamodbav:"lastObservationTime"`
+	LastNotifiedSeverity int    `dynamodbav:"lastNotifiedSeverity"`
+	BelowStreak          int    `dynamodbav:"belowStreak"`
+}
+
+func (d *DynamoStore) Get(ctx context.Context, stationID string) (StationState, bool, error) {
+	key, err := dynamodbattribute.MarshalMap(map[string]string{"stationId": stationID})
+	if err != nil {
+		return StationState{}, false, err
+	}
+	out, err := d.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.TableName),
+		Key:       key,
+	})
+	if err != nil {
+		return StationState{}, false, err
+	}
+	if len(out.Item) == 0 {
+		return StationState{}, false, nil
+	}
+
+	var item dynamoStationItem
+	if err := dynamodbattribute.UnmarshalMap(out.Item, &item); err != nil {
+		return StationState{}, false, err
+	}
+	return StationState{
+		LastObservationTime:  time.Unix(item.LastObservationTime, 0),
+		LastNotifiedSeverity: Level(item.LastNotifiedSeverity),
+		BelowStreak:          item.BelowStreak,
+	}, true, nil
+}
+
+func (d *DynamoStore) Put(ctx context.Context, stationID string, state StationState) error {
+	item, err := dynamodbattribute.MarshalMap(dynamoStationItem{
+		StationID:            stationID,
+		LastObservationTime:  state.LastObservationTime.Unix(),
+		LastNotifiedSeverity: int(state.LastNotifiedSeverity),
+		BelowStreak:          state.BelowStreak,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = d.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.TableName),
+		Item:      item,
+	})
+	return err
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// forecastLeadHours is how far ahead forecastMaxPop looks when summarizing
+// the upcoming forecast in a notification.
+const forecastLeadHours = 3
+
+// HourlyForecast is one hour of forecast precipitation probability for a
+// station. JMA's forecast API only reports a probability (%), not an
+// expected mm/h amount, so pop is a percentage, not a precipitation
+// reading, and must never be run through the mm-based Severity ladder.
+type HourlyForecast struct {
+	date time.Time
+	pop  float64
+}
+
+// CombinedReport merges an observed reading with the upcoming forecast so a
+// notification can carry lead time instead of just the current reading.
+type CombinedReport struct {
+	Observed Precipitation
+	Forecast []HourlyForecast
+}
+
+// ForecastProvider fetches the upcoming precipitation forecast for a
+// station.
+type ForecastProvider interface {
+	Forecast(ctx context.Context, station StationConfig) ([]HourlyForecast, error)
+}
+
+// jmaForecastResponse is the small subset of JMA's
+// forecast/data/forecast/<area>.json structure we need: the precipitation
+// probability (%) time series for each forecast area.
+type jmaForecastResponse []struct {
+	TimeSeries []struct {
+		TimeDefines []string `json:"timeDefines"`
+		Areas       []struct {
+			Area struct {
+				Code string `json:"code"`
+			} `json:"area"`
+			Pops []string `json:"pops"`
+		} `json:"areas"`
+	} `json:"timeSeries"`
+}
+
+// JMAForecastProvider fetches forecasts from JMA's public forecast API.
+//
+// JMA's forecast JSON only carries a precipitation probability (%) per time
+// slot, not an expected mm/h figure like the observation CSV does, so the
+// HourlyForecast entries it returns carry a pop, not an mm reading.
+type JMAForecastProvider struct{}
+
+func (JMAForecastProvider) Forecast(ctx context.Context, station StationConfig) ([]HourlyForecast, error) {
+	if station.ForecastAreaCode == "" {
+		return nil, nil
+	}
+
+	url := fmt.Sprintf("https://www.jma.go.jp/bosai/forecast/data/forecast/%s.json", station.ForecastAreaCode)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var parsed jmaForecastResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	var forecasts []HourlyForecast
+	for _, series := range parsed {
+		for _, ts := range series.TimeSeries {
+			for _, area := range ts.Areas {
+				if area.Area.Code != station.ForecastAreaCode || len(area.Pops) == 0 {
+					continue
+				}
+				for i, pop := range area.Pops {
+					if i >= len(ts.TimeDefines) {
+						break
+					}
+					t, err := time.Parse(time.RFC3339, ts.TimeDefines[i])
+					if err != nil {
+						continue
+					}
+					popValue, err := strconv.ParseFloat(pop, 64)
+					if err != nil {
+						continue
+					}
+					forecasts = append(forecasts, HourlyForecast{date: t, pop: popValue})
+				}
+			}
+		}
+	}
+	return forecasts, nil
+}
+
+// forecastMaxPop returns the highest precipitation probability among the
+// forecast entries falling within the next forecastLeadHours hours of now.
+// Probabilities for neighbouring time slots aren't additive, so this takes
+// the single most representative figure rather than summing them.
+func forecastMaxPop(forecast []HourlyForecast, now time.Time) float64 {
+	cutoff := now.Add(forecastLeadHours * time.Hour)
+	var max float64
+	for _, f := range forecast {
+		if f.date.After(now) && !f.date.After(cutoff) && f.pop > max {
+			max = f.pop
+		}
+	}
+	return max
+}
@@ -1,19 +1,11 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"errors"
+	"context"
 	"fmt"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/bitly/go-simplejson"
-	"golang.org/x/text/encoding/japanese"
-	"golang.org/x/text/transform"
-	"io"
 	"net/http"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 )
 
@@ -23,15 +15,8 @@ type Precipitation struct {
 }
 
 const (
-	kyotoNo            = "61286"
-	yearIndex          = 4
-	monthIndex         = 5
-	dayIndex           = 6
-	hourIndex          = 7
-	minuteIndex        = 8
-	precipitationIndex = 9
-	interval           = 40 // 観測から更新までが30分、実行間隔が10分
-	slackUrlKey        = "SLACK_API_KEY"
+	interval    = 40 // 観測から更新までが30分、実行間隔が10分
+	slackUrlKey = "SLACK_API_KEY"
 )
 
 func main() {
@@ -39,110 +24,122 @@ func main() {
 }
 
 func notifyPrecipitation() {
-	slackUrl := os.Getenv(slackUrlKey)
-	msg, err := getMessage()
+	store, err := buildStore()
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, "an error has occurred when fetching precipitation data")
+		_, _ = fmt.Fprintln(os.Stderr, "an error has occurred when initializing the state store")
 		_, _ = fmt.Fprintln(os.Stderr, err.Error())
 		return
 	}
-	if msg == "" {
-		_, _ = fmt.Fprintln(os.Stderr, "nothing to inform")
-		return
-	}
 
-	fmt.Println(msg)
-	json, err := createJsonBody(msg)
+	events, err := getMessages(store)
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, "an error has occurred when creating json body")
+		_, _ = fmt.Fprintln(os.Stderr, "an error has occurred when fetching precipitation data")
 		_, _ = fmt.Fprintln(os.Stderr, err.Error())
 		return
 	}
-	sendJson(json, slackUrl)
-}
-
-func sendJson(json []byte, slackUrl string) {
-	_, _ = http.Post(slackUrl, "application/json", bytes.NewBuffer(json))
+	if len(events) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "nothing to inform")
+		return
+	}
 
+	ctx := context.Background()
+	for _, event := range events {
+		fmt.Println(event.Text)
+		notifier := buildNotifier(event.Station)
+		if err := notifier.Notify(ctx, event); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "an error has occurred when sending notification")
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		}
+	}
 }
 
-func createJsonBody(message string) ([]byte, error) {
-	json := simplejson.New()
-	json.Set("text", message)
-	out := make([]byte, 0, len(message)*2)
-	out, err := json.MarshalJSON()
-	return out, err
-}
+// getMessages loads the station configuration, fetches the latest JMA
+// observation table and each station's forecast, and returns one
+// notification event per configured station whose precipitation has
+// crossed its threshold and whose notification state (per store) says
+// it's time to notify again.
+func getMessages(store Store) ([]Event, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
 
-func getMessage() (string, error) {
 	response, err := http.Get("http://www.data.jma.go.jp/obd/stats/data/mdrr/pre_rct/alltable/pre1h00_rct.csv")
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	kyotoPrecipitation, err := extractKyotoPrecipitation(response.Body)
+	precipitations, err := extractPrecipitations(response.Body, cfg.Stations)
 	if err != nil {
-		return "", err
-	}
-	if kyotoPrecipitation == nil {
-		return "", errors.New("指定の降水量データが見つかりませんでした")
+		return nil, err
 	}
+
+	ctx := context.Background()
 	currentTime := time.Now()
-	if sub := currentTime.Sub(kyotoPrecipitation.date); sub.Minutes() > interval {
-		return "", errors.New("指定の間隔より古いデータが見つかりました")
-	}
-	base := fmt.Sprintf("%02d:%02d〜の京都の一時間降水量は%.1fmmです\n",
-		kyotoPrecipitation.date.Hour(),
-		kyotoPrecipitation.date.Minute(),
-		kyotoPrecipitation.precipitation)
-	var additionalMessage string
-	if kyotoPrecipitation.precipitation >= 80 {
-		additionalMessage = "猛烈な雨です!"
-	} else if kyotoPrecipitation.precipitation >= 50 {
-		additionalMessage = "非常に激しい雨が降ります"
-	} else if kyotoPrecipitation.precipitation >= 30 {
-		additionalMessage = "激しい雨が降ります"
-	} else if kyotoPrecipitation.precipitation >= 20 {
-		additionalMessage = "強い雨が降ります"
-	} else if kyotoPrecipitation.precipitation >= 10 {
-		additionalMessage = "やや強い雨が降ります"
-	} else if kyotoPrecipitation.precipitation >= 5 {
-		additionalMessage = "やや本降りと言えます"
-	} else if kyotoPrecipitation.precipitation >= 2 {
-		additionalMessage = "傘が必要になるかもしれません"
-	} else if kyotoPrecipitation.precipitation >= 1 {
-		additionalMessage = "シトシトとした雨が降ります"
-	} else {
-		return "", nil
-	}
-	return base + additionalMessage, nil
+	var events []Event
+	for _, station := range cfg.Stations {
+		precipitation, ok := precipitations[station.ID]
+		if !ok {
+			continue
+		}
+		if sub := currentTime.Sub(precipitation.date); sub.Minutes() > interval {
+			continue
+		}
 
-}
+		// Below-threshold observations still advance the store as
+		// LevelNone, so a station's last-notified severity decays once
+		// rain stops instead of getting stuck at its last active tier.
+		level := Severity(precipitation.precipitation)
+		if precipitation.precipitation < station.ThresholdMM {
+			level = LevelNone
+		}
 
-func extractKyotoPrecipitation(reader io.Reader) (*Precipitation, error) {
-	scanner := bufio.NewScanner(transform.NewReader(reader, japanese.ShiftJIS.NewDecoder()))
-	for scanner.Scan() {
-		line := scanner.Text()
-		records := strings.Split(line, ",")
-		if records[0] != kyotoNo {
+		prevState, _, err := store.Get(ctx, station.ID)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "an error has occurred when reading notification state, continuing without it")
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		}
+		notify, nextState := decideNotification(prevState, precipitation.date, level)
+		if err := store.Put(ctx, station.ID, nextState); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "an error has occurred when writing notification state")
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		}
+		if !notify || level == LevelNone {
 			continue
 		}
 
-		givenTimeS := fmt.Sprintf("%s-%s-%sT%s:%s:00+09:00",
-			records[yearIndex],
-			records[monthIndex],
-			records[dayIndex],
-			records[hourIndex],
-			records[minuteIndex])
-		givenTime, err := time.Parse(time.RFC3339, givenTimeS)
+		forecast, err := (JMAForecastProvider{}).Forecast(ctx, station)
 		if err != nil {
-			return nil, err
+			_, _ = fmt.Fprintln(os.Stderr, "an error has occurred when fetching the forecast, continuing with observed data only")
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		}
+		report := CombinedReport{Observed: precipitation, Forecast: forecast}
+
+		text := formatMessage(station, report)
+		if text == "" {
+			continue
 		}
-		precipitation, err := strconv.ParseFloat(records[precipitationIndex], 64)
-		return &Precipitation{
-			date:          givenTime,
-			precipitation: precipitation,
-		}, nil
+		events = append(events, Event{Station: station, Precipitation: precipitation, Text: text})
+	}
+	return events, nil
+}
+
+// formatMessage renders the notification text for a single station's
+// combined report, or "" if the observed precipitation is too light to be
+// worth mentioning.
+func formatMessage(station StationConfig, report CombinedReport) string {
+	level := Severity(report.Observed.precipitation)
+	if level == LevelNone {
+		return ""
+	}
+	message := fmt.Sprintf("%02d:%02d〜の%sの一時間降水量は%.1fmmです\n%s",
+		report.Observed.date.Hour(),
+		report.Observed.date.Minute(),
+		station.Name,
+		report.Observed.precipitation,
+		level.Message())
 
+	if pop := forecastMaxPop(report.Forecast, report.Observed.date); pop > 0 {
+		message += fmt.Sprintf("\n今後%d時間の降水確率は最大%.0f%%です(予報)", forecastLeadHours, pop)
 	}
-	return nil, nil
+	return message
 }
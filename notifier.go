@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Event is a single rendered precipitation notification bound for one
+// station.
+type Event struct {
+	Station       StationConfig
+	Precipitation Precipitation
+	Text          string
+}
+
+// Notifier delivers an Event to some external channel.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// MultiNotifier fans an Event out to every wrapped Notifier, collecting
+// rather than short-circuiting on individual failures so one broken channel
+// doesn't silently swallow the others.
+type MultiNotifier []Notifier
+
+func (m MultiNotifier) Notify(ctx context.Context, event Event) error {
+	var errs []string
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d/%d notifiers failed: %s", len(errs), len(m), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+const (
+	postRetryCount = 3
+	postRetryWait  = 500 * time.Millisecond
+)
+
+// postJSON POSTs body to url as application/json, retrying with exponential
+// backoff on transport errors or non-2xx responses.
+func postJSON(ctx context.Context, url string, body []byte) error {
+	var lastErr error
+	for attempt := 0; attempt < postRetryCount; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(postRetryWait * time.Duration(1<<uint(attempt-1))):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+	return lastErr
+}
+
+// SlackNotifier posts a Block Kit message, with a severity-colored
+// attachment, to a Slack Incoming Webhook.
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+func (s SlackNotifier) Notify(ctx context.Context, event Event) error {
+	if s.WebhookURL == "" {
+		return errors.New("slack webhook url is empty")
+	}
+	body := map[string]interface{}{
+		"blocks": []map[string]interface{}{
+			{
+				"type": "section",
+				"text": map[string]string{
+					"type": "mrkdwn",
+					"text": event.Text,
+				},
+			},
+		},
+		"attachments": []map[string]interface{}{
+			{
+				"color": Severity(event.Precipitation.precipitation).Color(),
+				"text":  event.Text,
+			},
+		},
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.WebhookURL, payload)
+}
+
+// DiscordNotifier posts to a Discord incoming webhook.
+type DiscordNotifier struct {
+	WebhookURL string
+}
+
+func (d DiscordNotifier) Notify(ctx context.Context, event Event) error {
+	if d.WebhookURL == "" {
+		return errors.New("discord webhook url is empty")
+	}
+	payload, err := json.Marshal(map[string]string{"content": event.Text})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, d.WebhookURL, payload)
+}
+
+// TeamsNotifier posts a MessageCard to a Microsoft Teams incoming webhook.
+type TeamsNotifier struct {
+	WebhookURL string
+}
+
+func (t TeamsNotifier) Notify(ctx context.Context, event Event) error {
+	if t.WebhookURL == "" {
+		return errors.New("teams webhook url is empty")
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "http://schema.org/extensions",
+		"themeColor": strings.TrimPrefix(Severity(event.Precipitation.precipitation).Color(), "#"),
+		"title":      event.Station.Name,
+		"text":       event.Text,
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, t.WebhookURL, payload)
+}
+
+// WebhookNotifier posts a generic JSON payload to an arbitrary URL, for
+// users who want to wire this up to their own service.
+type WebhookNotifier struct {
+	URL string
+}
+
+func (w WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	if w.URL == "" {
+		return errors.New("webhook url is empty")
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"station":       event.Station.ID,
+		"stationName":   event.Station.Name,
+		"precipitation": event.Precipitation.precipitation,
+		"observedAt":    event.Precipitation.date,
+		"text":          event.Text,
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, w.URL, payload)
+}
+
+// StdoutNotifier writes the notification text to stdout, for local testing
+// without wiring up any external service.
+type StdoutNotifier struct{}
+
+func (StdoutNotifier) Notify(_ context.Context, event Event) error {
+	_, err := fmt.Println(event.Text)
+	return err
+}
+
+const (
+	notifierBackendsKey = "NOTIFIER_BACKENDS"
+	discordWebhookKey   = "DISCORD_WEBHOOK_URL"
+	teamsWebhookKey     = "TEAMS_WEBHOOK_URL"
+	genericWebhookKey   = "WEBHOOK_URL"
+)
+
+// buildNotifier assembles a MultiNotifier from the comma-separated
+// NOTIFIER_BACKENDS env var (one or more of "slack", "discord", "teams",
+// "webhook", "stdout"; defaults to "slack") plus each backend's associated
+// webhook URL. Slack uses the station's own webhook, falling back to
+// SLACK_API_KEY when no per-station URL is set.
+func buildNotifier(station StationConfig) Notifier {
+	backends := os.Getenv(notifierBackendsKey)
+	if backends == "" {
+		backends = "slack"
+	}
+
+	var multi MultiNotifier
+	for _, backend := range strings.Split(backends, ",") {
+		switch strings.TrimSpace(backend) {
+		case "slack":
+			url := station.SlackWebhookURL
+			if url == "" {
+				url = os.Getenv(slackUrlKey)
+			}
+			multi = append(multi, SlackNotifier{WebhookURL: url})
+		case "discord":
+			multi = append(multi, DiscordNotifier{WebhookURL: os.Getenv(discordWebhookKey)})
+		case "teams":
+			multi = append(multi, TeamsNotifier{WebhookURL: os.Getenv(teamsWebhookKey)})
+		case "webhook":
+			multi = append(multi, WebhookNotifier{URL: os.Getenv(genericWebhookKey)})
+		case "stdout":
+			multi = append(multi, StdoutNotifier{})
+		}
+	}
+	return multi
+}
@@ -0,0 +1,86 @@
+package main
+
+// Level classifies a precipitation reading (mm/h) against JMA's descriptive
+// rainfall ladder. It's used by the observed notification path and by the
+// color coding in the chat notifiers; forecast entries carry a probability
+// (%), not mm/h, and are not classified by it.
+type Level int
+
+const (
+	LevelNone Level = iota
+	LevelDrizzle
+	LevelUmbrella
+	LevelSteady
+	LevelFairlyStrong
+	LevelStrong
+	LevelHeavy
+	LevelVeryHeavy
+	LevelViolent
+)
+
+// Severity classifies a precipitation reading (mm/h) using the same
+// thresholds the original notification ladder used.
+func Severity(mm float64) Level {
+	switch {
+	case mm >= 80:
+		return LevelViolent
+	case mm >= 50:
+		return LevelVeryHeavy
+	case mm >= 30:
+		return LevelHeavy
+	case mm >= 20:
+		return LevelStrong
+	case mm >= 10:
+		return LevelFairlyStrong
+	case mm >= 5:
+		return LevelSteady
+	case mm >= 2:
+		return LevelUmbrella
+	case mm >= 1:
+		return LevelDrizzle
+	default:
+		return LevelNone
+	}
+}
+
+// Message returns the Japanese description used in notification text for
+// this severity level, or "" for LevelNone, meaning there's nothing worth
+// reporting.
+func (l Level) Message() string {
+	switch l {
+	case LevelViolent:
+		return "猛烈な雨です!"
+	case LevelVeryHeavy:
+		return "非常に激しい雨が降ります"
+	case LevelHeavy:
+		return "激しい雨が降ります"
+	case LevelStrong:
+		return "強い雨が降ります"
+	case LevelFairlyStrong:
+		return "やや強い雨が降ります"
+	case LevelSteady:
+		return "やや本降りと言えます"
+	case LevelUmbrella:
+		return "傘が必要になるかもしれません"
+	case LevelDrizzle:
+		return "シトシトとした雨が降ります"
+	default:
+		return ""
+	}
+}
+
+// Color returns the notification attachment color for this severity level:
+// blue for 1-9mm light rain, orange for the 10-79mm strong-to-very-heavy
+// band, and red only once it reaches 猛烈な雨 (>=80mm), as specified.
+func (l Level) Color() string {
+	switch {
+	case l >= LevelViolent:
+		return "#ff0000"
+	case l >= LevelFairlyStrong:
+		return "#ffa500"
+	case l >= LevelDrizzle:
+		return "#4169e1"
+	default:
+		return "#cccccc"
+	}
+}
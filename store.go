@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// StationState is what the state store persists for a single station: the
+// timestamp of the most recent observation we already acted on, the
+// severity we last actually notified at, and how many consecutive
+// observations have since come in below that severity (used for
+// hysteresis).
+type StationState struct {
+	LastObservationTime  time.Time
+	LastNotifiedSeverity Level
+	BelowStreak          int
+}
+
+// Store persists per-station notification state across Lambda
+// invocations, so the same rain event doesn't fire a fresh notification
+// every 10 minutes.
+type Store interface {
+	Get(ctx context.Context, stationID string) (StationState, bool, error)
+	Put(ctx context.Context, stationID string, state StationState) error
+}
+
+// hysteresisStreak is how many consecutive observations a station's
+// severity must stay below its last-notified level before we allow a fresh
+// notification at that (lower) severity.
+const hysteresisStreak = 3
+
+// decideNotification applies the dedup/hysteresis rules to a new
+// observation: skip a repeat of the same observation, notify immediately
+// on a severity increase, suppress repeats at an unchanged severity, and
+// notify again only after hysteresisStreak consecutive observations below
+// the last-notified severity.
+func decideNotification(prev StationState, observationTime time.Time, level Level) (notify bool, next StationState) {
+	if !prev.LastObservationTime.IsZero() && prev.LastObservationTime.Equal(observationTime) {
+		return false, prev
+	}
+
+	switch {
+	case level > prev.LastNotifiedSeverity:
+		return true, StationState{LastObservationTime: observationTime, LastNotifiedSeverity: level}
+	case level < prev.LastNotifiedSeverity:
+		streak := prev.BelowStreak + 1
+		if streak >= hysteresisStreak {
+			return true, StationState{LastObservationTime: observationTime, LastNotifiedSeverity: level}
+		}
+		return false, StationState{LastObservationTime: observationTime, LastNotifiedSeverity: prev.LastNotifiedSeverity, BelowStreak: streak}
+	default:
+		return false, StationState{LastObservationTime: observationTime, LastNotifiedSeverity: prev.LastNotifiedSeverity}
+	}
+}
+
+// InMemoryStore is a Store backed by a process-local map. It's meant for
+// local runs and tests; state does not survive across Lambda invocations.
+type InMemoryStore struct {
+	mu     sync.Mutex
+	states map[string]StationState
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{states: make(map[string]StationState)}
+}
+
+func (s *InMemoryStore) Get(_ context.Context, stationID string) (StationState, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[stationID]
+	return state, ok, nil
+}
+
+func (s *InMemoryStore) Put(_ context.Context, stationID string, state StationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[stationID] = state
+	return nil
+}
+
+const (
+	stateStoreKey     = "STATE_STORE"
+	stateTableNameKey = "STATE_TABLE_NAME"
+)
+
+// buildStore selects the state backend from the STATE_STORE env var:
+// "dynamodb" (the default, backed by the table named in STATE_TABLE_NAME)
+// or "memory" for local runs.
+func buildStore() (Store, error) {
+	switch os.Getenv(stateStoreKey) {
+	case "memory":
+		return NewInMemoryStore(), nil
+	case "", "dynamodb":
+		tableName := os.Getenv(stateTableNameKey)
+		if tableName == "" {
+			return nil, errors.New("STATE_TABLE_NAME must be set when using the dynamodb state store")
+		}
+		return NewDynamoStore(tableName)
+	default:
+		return nil, fmt.Errorf("unknown state store %q", os.Getenv(stateStoreKey))
+	}
+}